@@ -0,0 +1,43 @@
+package api
+
+import "strings"
+
+// VersionSet is an ordered list of the major.minor API lines a lifecycle
+// binary supports concurrently, e.g. Platform API 0.9 and 0.10.
+type VersionSet []*Version
+
+// MustParseSet parses a comma-separated list of MAJOR.MINOR versions into a
+// VersionSet, such as the value baked in at build time via `-ldflags -X`. It
+// panics on a malformed entry, mirroring MustParse.
+func MustParseSet(commaSeparated string) VersionSet {
+	var set VersionSet
+	for _, v := range strings.Split(commaSeparated, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		set = append(set, MustParse(v))
+	}
+	return set
+}
+
+// Supports reports whether any version in the set is API-compatible with o,
+// per Version.IsSupersetOf.
+func (s VersionSet) Supports(o *Version) bool {
+	for _, v := range s {
+		if v.IsSupersetOf(o) {
+			return true
+		}
+	}
+	return false
+}
+
+// String renders the set as a comma-separated list of MAJOR.MINOR versions,
+// e.g. "0.9,0.10".
+func (s VersionSet) String() string {
+	strs := make([]string, len(s))
+	for i, v := range s {
+		strs[i] = v.String()
+	}
+	return strings.Join(strs, ",")
+}
@@ -0,0 +1,43 @@
+package api_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/buildpacks/lifecycle/api"
+)
+
+func TestVersionSet(t *testing.T) {
+	t.Run("parses a comma-separated list", func(t *testing.T) {
+		set := api.MustParseSet("0.8, 0.9,0.10")
+		require.Equal(t, "0.8,0.9,0.10", set.String())
+	})
+
+	t.Run("Supports matches any compatible version in the set", func(t *testing.T) {
+		set := api.MustParseSet("0.8,0.9")
+		require.True(t, set.Supports(api.MustParse("0.8")))
+		require.True(t, set.Supports(api.MustParse("0.9")))
+		require.False(t, set.Supports(api.MustParse("0.10")))
+	})
+}
+
+func TestValidate(t *testing.T) {
+	supported := api.MustParseSet("0.6,0.7")
+
+	t.Run("succeeds when a supported version is compatible", func(t *testing.T) {
+		err := api.Validate("some-buildpack", "1.2.3", api.MustParse("0.7"), supported)
+		require.NoError(t, err)
+	})
+
+	t.Run("fails with a structured error identifying the rejected buildpack", func(t *testing.T) {
+		err := api.Validate("some-buildpack", "1.2.3", api.MustParse("0.9"), supported)
+		require.Error(t, err)
+
+		validateErr, ok := err.(*api.ValidateError)
+		require.True(t, ok)
+		require.Equal(t, "some-buildpack", validateErr.Subject)
+		require.Equal(t, "1.2.3", validateErr.Version)
+		require.Contains(t, err.Error(), "some-buildpack@1.2.3")
+	})
+}
@@ -0,0 +1,41 @@
+package api
+
+import "fmt"
+
+// ValidateError reports that a single buildpack or platform declared an API
+// version that none of the lifecycle's supported versions are compatible
+// with.
+type ValidateError struct {
+	// Subject identifies what declared the rejected API, e.g. a buildpack ID
+	// or "platform".
+	Subject string
+	// Version is the subject's declared version, if known (buildpacks only).
+	Version string
+	// Required is the API version the subject declared support for.
+	Required *Version
+	// Supported is the set of API versions the lifecycle supports.
+	Supported VersionSet
+}
+
+func (e *ValidateError) Error() string {
+	if e.Version != "" {
+		return fmt.Sprintf(
+			"buildpack '%s@%s' requires API version '%s' which is incompatible with the lifecycle's supported API versions (%s)",
+			e.Subject, e.Version, e.Required, e.Supported,
+		)
+	}
+	return fmt.Sprintf(
+		"%s requires API version '%s' which is incompatible with the lifecycle's supported API versions (%s)",
+		e.Subject, e.Required, e.Supported,
+	)
+}
+
+// Validate checks that required is compatible with at least one version in
+// supported, returning a *ValidateError identifying subject (and, for
+// buildpacks, its version) if not.
+func Validate(subject, version string, required *Version, supported VersionSet) error {
+	if supported.Supports(required) {
+		return nil
+	}
+	return &ValidateError{Subject: subject, Version: version, Required: required, Supported: supported}
+}
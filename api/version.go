@@ -4,15 +4,23 @@ import (
 	"fmt"
 	"regexp"
 	"strconv"
+	"strings"
 
 	"github.com/pkg/errors"
 )
 
+// regex matches the historical `MAJOR.MINOR` form used for API negotiation.
 var regex = regexp.MustCompile(`^v?(\d+)\.?(\d*)$`)
 
+// semverRegex matches a full SemVer 2.0 string, e.g. `1.2.3-alpha.1+build.5`.
+var semverRegex = regexp.MustCompile(`^v?(\d+)\.(\d+)\.(\d+)(?:-([0-9A-Za-z-.]+))?(?:\+([0-9A-Za-z-.]+))?$`)
+
 type Version struct {
 	Major,
-	Minor uint64
+	Minor,
+	Patch uint64
+	PreRelease string
+	Build      string
 }
 
 func MustParse(v string) *Version {
@@ -24,7 +32,32 @@ func MustParse(v string) *Version {
 	return version
 }
 
+// NewVersion parses v as either the legacy `MAJOR.MINOR` API version form or a
+// full SemVer 2.0 string (`MAJOR.MINOR.PATCH[-PRERELEASE][+BUILD]`).
 func NewVersion(v string) (*Version, error) {
+	if matches := semverRegex.FindStringSubmatch(v); matches != nil {
+		major, err := strconv.ParseUint(matches[1], 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing Major '%s'", matches[1])
+		}
+		minor, err := strconv.ParseUint(matches[2], 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing Minor '%s'", matches[2])
+		}
+		patch, err := strconv.ParseUint(matches[3], 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing Patch '%s'", matches[3])
+		}
+
+		return &Version{
+			Major:      major,
+			Minor:      minor,
+			Patch:      patch,
+			PreRelease: matches[4],
+			Build:      matches[5],
+		}, nil
+	}
+
 	matches := regex.FindAllStringSubmatch(v, -1)
 	if len(matches) == 0 {
 		return nil, errors.Errorf("could not parse '%s' as version", v)
@@ -55,10 +88,25 @@ func NewVersion(v string) (*Version, error) {
 	return &Version{Major: major, Minor: minor}, nil
 }
 
+// String renders the MAJOR.MINOR form used for API negotiation, preserved as
+// the default for backward compat. Use FullString for the extended SemVer
+// representation including patch, pre-release, and build metadata.
 func (v *Version) String() string {
 	return fmt.Sprintf("%d.%d", v.Major, v.Minor)
 }
 
+// FullString renders the complete SemVer 2.0 representation of v.
+func (v *Version) FullString() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.PreRelease != "" {
+		s += "-" + v.PreRelease
+	}
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+	return s
+}
+
 // MarshalText makes Version satisfy the encoding.TextMarshaler interface.
 func (v *Version) MarshalText() ([]byte, error) {
 	return []byte(v.String()), nil
@@ -73,8 +121,7 @@ func (v *Version) UnmarshalText(text []byte) error {
 		return errors.Wrapf(err, "invalid api version '%s'", s)
 	}
 
-	v.Major = parsedVersion.Major
-	v.Minor = parsedVersion.Minor
+	*v = *parsedVersion
 
 	return nil
 }
@@ -83,6 +130,12 @@ func (v *Version) Equal(o *Version) bool {
 	return v.Compare(o) == 0
 }
 
+// Compare returns -1, 0, or 1 if v is less than, equal to, or greater than o.
+// Major, Minor, and Patch are compared numerically. PreRelease follows SemVer
+// 2.0 precedence: a version with a pre-release has lower precedence than the
+// associated normal version, and dot-separated identifiers are compared
+// numerically if both are numeric, or lexically otherwise. Build metadata is
+// ignored.
 func (v *Version) Compare(o *Version) int {
 	if v.Major != o.Major {
 		if v.Major < o.Major {
@@ -104,9 +157,80 @@ func (v *Version) Compare(o *Version) int {
 		}
 	}
 
+	if v.Patch != o.Patch {
+		if v.Patch < o.Patch {
+			return -1
+		}
+
+		if v.Patch > o.Patch {
+			return 1
+		}
+	}
+
+	return comparePreRelease(v.PreRelease, o.PreRelease)
+}
+
+// comparePreRelease implements SemVer 2.0 pre-release precedence: a version
+// lacking a pre-release always outranks one that has it.
+func comparePreRelease(v, o string) int {
+	if v == o {
+		return 0
+	}
+	if v == "" {
+		return 1
+	}
+	if o == "" {
+		return -1
+	}
+
+	vIDs := strings.Split(v, ".")
+	oIDs := strings.Split(o, ".")
+
+	for i := 0; i < len(vIDs) && i < len(oIDs); i++ {
+		if c := compareIdentifier(vIDs[i], oIDs[i]); c != 0 {
+			return c
+		}
+	}
+
+	if len(vIDs) != len(oIDs) {
+		if len(vIDs) < len(oIDs) {
+			return -1
+		}
+		return 1
+	}
+
 	return 0
 }
 
+// compareIdentifier compares a single dot-separated pre-release identifier.
+// Numeric identifiers are compared numerically and always have lower
+// precedence than alphanumeric ones; otherwise identifiers are compared
+// lexically in ASCII order.
+func compareIdentifier(v, o string) int {
+	vNum, vErr := strconv.ParseUint(v, 10, 64)
+	oNum, oErr := strconv.ParseUint(o, 10, 64)
+
+	if vErr == nil && oErr == nil {
+		switch {
+		case vNum < oNum:
+			return -1
+		case vNum > oNum:
+			return 1
+		default:
+			return 0
+		}
+	}
+
+	if vErr == nil {
+		return -1
+	}
+	if oErr == nil {
+		return 1
+	}
+
+	return strings.Compare(v, o)
+}
+
 // IsAPICompatible determines if the lifecycle's API version is compatible with another's API version.
 //
 // Example Usage Pseudocode:
@@ -116,7 +240,7 @@ func (v *Version) Compare(o *Version) int {
 //
 func (v *Version) IsSupersetOf(o *Version) bool {
 	if v.Major == 0 {
-		return v.Equal(o)
+		return v.Major == o.Major && v.Minor == o.Minor
 	}
 	return v.Major == o.Major && v.Minor >= o.Minor
 }
@@ -0,0 +1,85 @@
+package api_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/buildpacks/lifecycle/api"
+)
+
+func TestNewVersion(t *testing.T) {
+	t.Run("MAJOR.MINOR form", func(t *testing.T) {
+		v, err := api.NewVersion("1.2")
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), v.Major)
+		require.Equal(t, uint64(2), v.Minor)
+		require.Equal(t, "1.2", v.String())
+	})
+
+	t.Run("full semver form", func(t *testing.T) {
+		v, err := api.NewVersion("1.2.3-alpha.1+build.5")
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), v.Major)
+		require.Equal(t, uint64(2), v.Minor)
+		require.Equal(t, uint64(3), v.Patch)
+		require.Equal(t, "alpha.1", v.PreRelease)
+		require.Equal(t, "build.5", v.Build)
+		require.Equal(t, "1.2", v.String())
+		require.Equal(t, "1.2.3-alpha.1+build.5", v.FullString())
+	})
+
+	t.Run("invalid version", func(t *testing.T) {
+		_, err := api.NewVersion("not-a-version")
+		require.Error(t, err)
+	})
+}
+
+func TestVersionCompare(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		v, o     string
+		expected int
+	}{
+		{"equal", "1.2.3", "1.2.3", 0},
+		{"major differs", "2.0.0", "1.9.9", 1},
+		{"minor differs", "1.3.0", "1.2.9", 1},
+		{"patch differs", "1.2.4", "1.2.3", 1},
+		{"pre-release is lower than normal", "1.0.0-alpha", "1.0.0", -1},
+		{"pre-release numeric identifiers compared numerically", "1.0.0-alpha.2", "1.0.0-alpha.10", -1},
+		{"pre-release alpha identifiers outrank numeric", "1.0.0-alpha.beta", "1.0.0-alpha.1", 1},
+		{"pre-release identifiers compared lexically", "1.0.0-alpha", "1.0.0-beta", -1},
+		{"build metadata is ignored", "1.0.0+build.1", "1.0.0+build.2", 0},
+		{"fewer identifiers has lower precedence", "1.0.0-alpha", "1.0.0-alpha.1", -1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			v, err := api.NewVersion(tc.v)
+			require.NoError(t, err)
+			o, err := api.NewVersion(tc.o)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.expected, v.Compare(o))
+			require.Equal(t, -tc.expected, o.Compare(v))
+		})
+	}
+}
+
+func TestVersionIsSupersetOf(t *testing.T) {
+	t.Run("ignores patch and pre-release differences", func(t *testing.T) {
+		v := api.MustParse("1.2.3")
+		o := api.MustParse("1.2.0-beta.1")
+		require.True(t, v.IsSupersetOf(o))
+	})
+
+	t.Run("rejects a higher minor", func(t *testing.T) {
+		v := api.MustParse("1.2.0")
+		o := api.MustParse("1.3.0")
+		require.False(t, v.IsSupersetOf(o))
+	})
+
+	t.Run("major 0 requires exact match", func(t *testing.T) {
+		v := api.MustParse("0.2.0")
+		o := api.MustParse("0.1.0")
+		require.False(t, v.IsSupersetOf(o))
+	})
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"github.com/buildpacks/lifecycle"
+	"github.com/buildpacks/lifecycle/api"
+	"github.com/buildpacks/lifecycle/cmd"
+)
+
+// supportedPlatformAPIs and supportedBuildpackAPIs are the sets of
+// major.minor API lines this lifecycle binary supports, baked in at build
+// time via:
+//
+//	-ldflags "-X main.supportedPlatformAPIsStr=0.8,0.9,0.10 -X main.supportedBuildpackAPIsStr=0.6,0.7"
+var (
+	supportedPlatformAPIsStr  = "0.9"
+	supportedBuildpackAPIsStr = "0.7"
+
+	supportedPlatformAPIs  = api.MustParseSet(supportedPlatformAPIsStr)
+	supportedBuildpackAPIs = api.MustParseSet(supportedBuildpackAPIsStr)
+)
+
+// verifyPlatformAPI validates that platformAPI is compatible with one of the
+// lifecycle's supportedPlatformAPIs.
+func verifyPlatformAPI(platformAPI string) error {
+	requested, err := api.NewVersion(platformAPI)
+	if err != nil {
+		return cmd.FailErrCode(err, cmd.CodeInvalidArgs, "parse platform API")
+	}
+
+	if err := api.Validate("platform", "", requested, supportedPlatformAPIs); err != nil {
+		return cmd.FailErrCode(err, cmd.CodeIncompatiblePlatformAPI, "validate platform API")
+	}
+	return nil
+}
+
+// verifyBuildpackApis validates that every buildpack in group declares a
+// Buildpack API that is compatible with one of the lifecycle's
+// supportedBuildpackAPIs.
+func verifyBuildpackApis(group lifecycle.BuildpackGroup) error {
+	for _, bp := range group.Group {
+		requested, err := api.NewVersion(bp.API)
+		if err != nil {
+			return cmd.FailErrCode(err, cmd.CodeInvalidArgs, "parse buildpack API")
+		}
+
+		if err := api.Validate(bp.ID, bp.Version, requested, supportedBuildpackAPIs); err != nil {
+			return cmd.FailErrCode(err, cmd.CodeIncompatibleBuildpackAPI, "validate buildpack API")
+		}
+	}
+	return nil
+}
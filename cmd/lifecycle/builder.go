@@ -1,14 +1,16 @@
 package main
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/BurntSushi/toml"
+	"io"
 	"log"
+	"net"
 	"os"
-	"os/exec"
-	"path/filepath"
-	"syscall"
+
+	"golang.org/x/sys/unix"
 
 	"github.com/buildpacks/lifecycle"
 	"github.com/buildpacks/lifecycle/api"
@@ -16,6 +18,8 @@ import (
 	"github.com/buildpacks/lifecycle/env"
 	"github.com/buildpacks/lifecycle/launch"
 	"github.com/buildpacks/lifecycle/priv"
+	"github.com/buildpacks/lifecycle/rpc"
+	"github.com/buildpacks/lifecycle/sbom"
 	"github.com/buildpacks/lifecycle/snapshot"
 )
 
@@ -35,6 +39,8 @@ type buildArgs struct {
 	appDir        string
 	platformDir   string
 	platformAPI   string
+	snapshotter   string
+	sbomFormats   string
 }
 
 func (b *buildCmd) Init() {
@@ -45,6 +51,8 @@ func (b *buildCmd) Init() {
 	cmd.FlagAppDir(&b.appDir)
 	cmd.FlagPlatformDir(&b.platformDir)
 	cmd.FlagStackGroupPath(&b.stackGroupPath)
+	cmd.FlagSnapshotter(&b.snapshotter)
+	cmd.FlagSBOMFormats(&b.sbomFormats)
 }
 
 func (b *buildCmd) Args(nargs int, args []string) error {
@@ -73,6 +81,16 @@ func (b *buildCmd) Exec() error {
 		return err
 	}
 
+	if err := verifyPlatformAPI(b.platformAPI); err != nil {
+		return err
+	}
+	if err := verifyBuildpackApis(group); err != nil {
+		return err
+	}
+	if err := verifyBuildpackApis(stackGroup); err != nil {
+		return err
+	}
+
 	if len(stackGroup.Group) > 0 {
 		return b.buildAll(group, stackGroup, plan)
 	}
@@ -80,18 +98,16 @@ func (b *buildCmd) Exec() error {
 }
 
 func (ba buildArgs) buildAll(group, stackGroup lifecycle.BuildpackGroup, plan lifecycle.BuildPlan) error {
-	if err := verifyBuildpackApis(group); err != nil {
-		return err
-	}
-
-	if err := verifyBuildpackApis(stackGroup); err != nil {
+	builder, err := ba.createBuilder(group, stackGroup, plan, os.Stdout, os.Stderr)
+	if err != nil {
 		return err
 	}
 
-	builder, err := ba.createBuilder(group, stackGroup, plan)
+	unmountSnapshotRoot, err := mountSnapshotRoot(builder.Snapshotter)
 	if err != nil {
-		return err
+		return cmd.FailErr(err, "expose snapshot root to stack buildpacks")
 	}
+	defer unmountSnapshotRoot()
 
 	if err = ba.stackBuild(builder); err != nil {
 		return err
@@ -105,9 +121,30 @@ func (ba buildArgs) buildAll(group, stackGroup lifecycle.BuildpackGroup, plan li
 	return nil
 }
 
+// mountSnapshotRoot bind-mounts snapshotter.Root() over the real root so a
+// stack buildpack writing to "/" actually lands where TakeSnapshot looks for
+// changes. For KanikoSnapshotter, Root() is already the real root and this
+// is a no-op; for OverlaySnapshotter, Root() is the overlay's merged view,
+// and without this bind mount stack buildpacks would keep writing straight
+// to the real root, leaving the overlay's upper dir - and so every
+// TakeSnapshot result - empty regardless of what they installed.
+func mountSnapshotRoot(snapshotter snapshot.Snapshotter) (func() error, error) {
+	root := snapshotter.Root()
+	if root == "/" {
+		return func() error { return nil }, nil
+	}
+
+	if err := unix.Mount(root, "/", "", unix.MS_BIND|unix.MS_REC, ""); err != nil {
+		return nil, fmt.Errorf("bind mount snapshot root over /: %w", err)
+	}
+	return func() error {
+		return unix.Unmount("/", unix.MNT_DETACH)
+	}, nil
+}
+
 func (ba buildArgs) stackBuild(builder *lifecycle.Builder) error {
 	// run stack buildpacks as root
-	_, err := builder.StackBuild()
+	layer, err := ba.callStackBuild(builder)
 	if err != nil {
 		if err, ok := err.(*lifecycle.Error); ok {
 			if err.Type == lifecycle.ErrTypeBuildpack {
@@ -116,27 +153,107 @@ func (ba buildArgs) stackBuild(builder *lifecycle.Builder) error {
 		}
 		return cmd.FailErrCode(err, cmd.CodeBuildError, "stack-build")
 	}
+
+	if err := ba.writeStackSBOM(builder, layer); err != nil {
+		return cmd.FailErrCode(err, cmd.CodeBuildError, "write stack sbom")
+	}
 	return nil
 }
 
-func (ba buildArgs) buildAsSubProcess() error {
-	exe, err := os.Executable()
-	if err != nil {
+// callStackBuild runs builder.StackBuild() over the same rpc.Conn/Registry
+// machinery buildAsSubProcess uses for user buildpacks, rather than calling
+// it directly. Unlike a user build, this does not cross into the unprivileged
+// worker process: stack buildpacks need the root privileges this process
+// already holds, and handing MethodStackBuild to the dropped-privilege worker
+// would reopen the privilege boundary buildAsSubProcess exists to enforce. So
+// this serves the request over an in-process net.Pipe instead, which means
+// both code paths speak the same rpc protocol - including the same
+// MethodCancel handling, which (see workerServer.handleCancel) only ever
+// refuses a StackBuild that hasn't started yet, not one already running.
+func (ba buildArgs) callStackBuild(builder *lifecycle.Builder) (snapshot.Layer, error) {
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+
+	reg := rpc.NewRegistry()
+	var cancelled cancelFlag
+	reg.Register(rpc.MethodCancel, func(params json.RawMessage, notify rpc.NotifyFunc) (interface{}, error) {
+		cancelled.set()
+		return "ok", nil
+	})
+	reg.Register(rpc.MethodStackBuild, func(params json.RawMessage, notify rpc.NotifyFunc) (interface{}, error) {
+		// Only refuses to start; can't interrupt builder.StackBuild() once
+		// it's running, since it takes no cancellation channel.
+		if cancelled.isSet() {
+			return nil, errors.New("stack build cancelled")
+		}
+		return builder.StackBuild()
+	})
+	reg.Register(rpc.MethodShutdown, func(params json.RawMessage, notify rpc.NotifyFunc) (interface{}, error) {
+		return "ok", nil
+	})
+
+	serveErrCh := make(chan error, 1)
+	go func() { serveErrCh <- rpc.Serve(rpc.NewConn(serverConn), reg) }()
+
+	client := rpc.NewClient(rpc.NewConn(clientConn))
+	result, callErr := client.Call(rpc.MethodStackBuild, nil)
+	if callErr != nil {
+		return snapshot.Layer{}, callErr
+	}
+
+	if _, err := client.Call(rpc.MethodShutdown, nil); err != nil {
+		return snapshot.Layer{}, err
+	}
+	<-serveErrCh
+
+	var layer snapshot.Layer
+	if err := json.Unmarshal(result, &layer); err != nil {
+		return snapshot.Layer{}, err
+	}
+	return layer, nil
+}
+
+// stackSBOMDir is the layersDir subdirectory writeStackSBOM writes to. It is
+// not named after any one stack buildpack ID: StackBuild takes a single
+// snapshot after running every buildpack in StackGroup, so the resulting
+// layer - and the SBOM describing it - covers the whole group, not any
+// individual buildpack.
+const stackSBOMDir = "stack"
+
+// writeStackSBOM generates the SBOM formats requested via -sbom-formats for
+// the stack buildpacks' combined snapshot layer, writing them once under
+// layersDir/stack/. Writing one copy per buildpack in the group would claim
+// each buildpack independently produced the same components, which isn't
+// true of a single combined snapshot.
+func (ba buildArgs) writeStackSBOM(builder *lifecycle.Builder, layer snapshot.Layer) error {
+	formats, err := sbom.ParseFormats(ba.sbomFormats)
+	if err != nil || len(formats) == 0 {
 		return err
 	}
+	if len(builder.StackGroup.Group) == 0 {
+		return nil
+	}
+
+	generator := builder.SBOMGenerator
+	if generator == nil {
+		generator = sbom.DefaultGenerator{}
+	}
+
+	return generator.Generate(layer, ba.layersDir, stackSBOMDir, formats)
+}
 
-	c := exec.Command(
-		filepath.Join(filepath.Dir(exe), "builder"),
-		fmt.Sprintf("-%s", cmd.FlagNameGroupPath), ba.groupPath,
-		fmt.Sprintf("-%s", cmd.FlagNamePlanPath), ba.planPath,
-		// TODO set other args
-	)
-	c.SysProcAttr = &syscall.SysProcAttr{}
-	c.SysProcAttr.Credential = &syscall.Credential{Uid: uint32(ba.uid), Gid: uint32(ba.gid)}
-	c.Stdout = os.Stdout
-	c.Stderr = os.Stderr
+// buildAsSubProcess runs the user buildpack build by delegating to the
+// long-lived, unprivileged build worker, starting it on first use. This
+// replaces the previous exec.Command-per-build approach, which paid a fresh
+// process startup cost on every call and gave the platform no way to cancel
+// an in-flight build.
+func (ba buildArgs) buildAsSubProcess() error {
+	w, err := ensureWorker(ba.uid, ba.gid)
+	if err != nil {
+		return cmd.FailErr(err, "start build worker")
+	}
 
-	return c.Run()
+	return w.Build(ba)
 }
 
 func (ba buildArgs) build(builder *lifecycle.Builder) error {
@@ -162,25 +279,36 @@ func (ba buildArgs) build(builder *lifecycle.Builder) error {
 	return nil
 }
 
-func (ba buildArgs) createBuilder(group, stackGroup lifecycle.BuildpackGroup, plan lifecycle.BuildPlan) (*lifecycle.Builder, error) {
-	stackSnapshotter, err := snapshot.NewKanikoSnapshotter("/")
+// createBuilder assembles a *lifecycle.Builder. out/err receive buildpack
+// output: the root process passes os.Stdout/os.Stderr directly, while the
+// build worker passes writers that relay each line over the RPC connection
+// as a NotificationLog instead, since the worker has no fds the platform is
+// watching.
+func (ba buildArgs) createBuilder(group, stackGroup lifecycle.BuildpackGroup, plan lifecycle.BuildPlan, out, errW io.Writer) (*lifecycle.Builder, error) {
+	snapshotterFactory, err := snapshot.NewFactory(snapshot.Kind(ba.snapshotter))
+	if err != nil {
+		return &lifecycle.Builder{}, cmd.FailErrCode(err, cmd.CodeInvalidArgs, "parse -snapshotter")
+	}
+
+	stackSnapshotter, err := snapshotterFactory("/")
 	if err != nil {
 		return &lifecycle.Builder{}, err
 	}
 
 	return &lifecycle.Builder{
-		AppDir:        ba.appDir,
-		LayersDir:     ba.layersDir,
-		PlatformDir:   ba.platformDir,
-		BuildpacksDir: ba.buildpacksDir,
-		PlatformAPI:   api.MustParse(ba.platformAPI),
-		Env:           env.NewBuildEnv(os.Environ()),
-		Group:         group,
-		StackGroup:    stackGroup,
-		Plan:          plan,
-		Out:           log.New(os.Stdout, "", 0),
-		Err:           log.New(os.Stderr, "", 0),
-		Snapshotter:   stackSnapshotter,
+		AppDir:             ba.appDir,
+		LayersDir:          ba.layersDir,
+		PlatformDir:        ba.platformDir,
+		BuildpacksDir:      ba.buildpacksDir,
+		PlatformAPI:        api.MustParse(ba.platformAPI),
+		Env:                env.NewBuildEnv(os.Environ()),
+		Group:              group,
+		StackGroup:         stackGroup,
+		Plan:               plan,
+		Out:                log.New(out, "", 0),
+		Err:                log.New(errW, "", 0),
+		Snapshotter:        stackSnapshotter,
+		SnapshotterFactory: snapshotterFactory,
 	}, nil
 }
 
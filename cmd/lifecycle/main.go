@@ -0,0 +1,33 @@
+package main
+
+import (
+	"flag"
+	"os"
+)
+
+// main covers the one bootstrap path these five commits add to the binary:
+// when re-invoked with -worker-socket (see startWorker in worker.go), the
+// process skips normal phase-command dispatch entirely and runs as the
+// persistent, unprivileged build worker until the parent sends it a
+// MethodShutdown request. Dispatch for the lifecycle's other phase commands
+// (detect/build/export/...) lives elsewhere in the binary and is unaffected.
+func main() {
+	for _, arg := range os.Args[1:] {
+		if arg == "-"+workerSocketFlag || arg == "--"+workerSocketFlag {
+			os.Exit(runWorkerMain(os.Args[1:]))
+		}
+	}
+}
+
+func runWorkerMain(args []string) int {
+	fs := flag.NewFlagSet("worker", flag.ContinueOnError)
+	sockPath := fs.String(workerSocketFlag, "", "unix socket to dial back into the parent lifecycle process")
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+
+	if err := runWorker(*sockPath); err != nil {
+		return 1
+	}
+	return 0
+}
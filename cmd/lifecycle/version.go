@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/buildpacks/lifecycle/cmd"
+)
+
+// versionCmd prints the set of Platform and Buildpack API versions this
+// lifecycle binary supports, so that `pack` and other platforms can
+// introspect compatibility before invoking the builder.
+type versionCmd struct{}
+
+func (v *versionCmd) Init() {}
+
+func (v *versionCmd) Args(nargs int, args []string) error {
+	if nargs != 0 {
+		return cmd.FailErrCode(fmt.Errorf("received unexpected arguments"), cmd.CodeInvalidArgs, "parse arguments")
+	}
+	return nil
+}
+
+func (v *versionCmd) Privileges() error {
+	return nil
+}
+
+func (v *versionCmd) Exec() error {
+	fmt.Printf("Platform APIs: %s\n", supportedPlatformAPIs)
+	fmt.Printf("Buildpack APIs: %s\n", supportedBuildpackAPIs)
+	return nil
+}
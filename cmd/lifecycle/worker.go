@@ -0,0 +1,288 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/buildpacks/lifecycle"
+	"github.com/buildpacks/lifecycle/cmd"
+	"github.com/buildpacks/lifecycle/rpc"
+)
+
+// workerSocketFlag is the flag the worker subprocess reads its Unix socket
+// path from. It is intentionally separate from the cmd package's flag set:
+// the worker is not a platform-facing phase command, just an implementation
+// detail of buildAsSubProcess.
+const workerSocketFlag = "worker-socket"
+
+// cancelFlag is a concurrency-safe bool. Serve dispatches each request on its
+// own goroutine, so a MethodCancel request can be handled while a
+// MethodBuild or MethodStackBuild request is still running on another
+// goroutine; a plain bool shared between those handlers would race.
+type cancelFlag struct {
+	mu      sync.Mutex
+	flagged bool
+}
+
+func (c *cancelFlag) set() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.flagged = true
+}
+
+func (c *cancelFlag) isSet() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.flagged
+}
+
+// buildWorkerParams is the wire form of buildArgs sent as the Params of a
+// MethodBuild request. buildArgs itself is unexported and so can't be
+// marshaled directly.
+type buildWorkerParams struct {
+	UID, GID      int
+	GroupPath     string
+	PlanPath      string
+	BuildpacksDir string
+	LayersDir     string
+	AppDir        string
+	PlatformDir   string
+	PlatformAPI   string
+	Snapshotter   string
+}
+
+func (ba buildArgs) toWorkerParams() buildWorkerParams {
+	return buildWorkerParams{
+		UID: ba.uid, GID: ba.gid,
+		GroupPath: ba.groupPath, PlanPath: ba.planPath,
+		BuildpacksDir: ba.buildpacksDir, LayersDir: ba.layersDir,
+		AppDir: ba.appDir, PlatformDir: ba.platformDir, PlatformAPI: ba.platformAPI,
+		Snapshotter: ba.snapshotter,
+	}
+}
+
+func (p buildWorkerParams) toBuildArgs() buildArgs {
+	return buildArgs{
+		uid: p.UID, gid: p.GID,
+		groupPath: p.GroupPath, planPath: p.PlanPath,
+		buildpacksDir: p.BuildpacksDir, layersDir: p.LayersDir,
+		appDir: p.AppDir, platformDir: p.PlatformDir, platformAPI: p.PlatformAPI,
+		snapshotter: p.Snapshotter,
+	}
+}
+
+// workerClient drives a persistent, unprivileged build worker over a Unix
+// socket in place of the one-exec-per-build subprocess buildAsSubProcess used
+// previously. The worker is spawned at most once per buildCmd invocation and
+// reused for every Build call, which removes per-buildpack process startup
+// cost in creator mode and gives the caller a Cancel method that a plain
+// exec.Command never exposed - though, see workerServer.handleBuild, Cancel
+// only stops a Build that hasn't started yet, not one already running.
+type workerClient struct {
+	proc *exec.Cmd
+	rpc  *rpc.Client
+	sock string
+}
+
+var (
+	workerOnce sync.Once
+	worker     *workerClient
+	workerErr  error
+)
+
+// ensureWorker lazily starts the build worker, dropping it to uid:gid via the
+// same syscall.Credential mechanism the old subprocess used.
+func ensureWorker(uid, gid int) (*workerClient, error) {
+	workerOnce.Do(func() {
+		worker, workerErr = startWorker(uid, gid)
+	})
+	return worker, workerErr
+}
+
+func startWorker(uid, gid int) (*workerClient, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+
+	sockPath, err := workerSocketPath()
+	if err != nil {
+		return nil, err
+	}
+	_ = os.Remove(sockPath)
+
+	ln, err := net.Listen("unix", sockPath)
+	if err != nil {
+		return nil, err
+	}
+	defer ln.Close()
+	defer os.Remove(sockPath)
+
+	proc := exec.Command(exe, fmt.Sprintf("-%s", workerSocketFlag), sockPath)
+	proc.SysProcAttr = &syscall.SysProcAttr{Credential: &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid)}}
+	proc.Stderr = os.Stderr
+	// the worker streams buildpack stdout/stderr back as NotificationLog
+	// messages (see notifyWriter in runWorker's MethodBuild handler) rather
+	// than inheriting our fds, so its own Stdout is reserved for anything it
+	// logs outside of a build and can safely go where Stderr does.
+	proc.Stdout = os.Stderr
+
+	if err := proc.Start(); err != nil {
+		return nil, err
+	}
+
+	conn, err := ln.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	client := rpc.NewClient(rpc.NewConn(conn))
+	client.OnNotify = func(method string, params json.RawMessage) {
+		if method != rpc.NotificationLog {
+			return
+		}
+		var line rpc.LogParams
+		if err := json.Unmarshal(params, &line); err != nil {
+			return
+		}
+		if line.Stderr {
+			fmt.Fprint(os.Stderr, line.Text)
+		} else {
+			fmt.Fprint(os.Stdout, line.Text)
+		}
+	}
+
+	return &workerClient{proc: proc, rpc: client, sock: sockPath}, nil
+}
+
+// notifyWriter adapts a Handler's NotifyFunc into an io.Writer, so a
+// *log.Logger can stream buildpack output back to the caller as
+// rpc.NotificationLog messages instead of writing to a local fd.
+type notifyWriter struct {
+	notify rpc.NotifyFunc
+	stderr bool
+}
+
+func (w notifyWriter) Write(p []byte) (int, error) {
+	w.notify(rpc.NotificationLog, &rpc.LogParams{Text: string(p), Stderr: w.stderr})
+	return len(p), nil
+}
+
+// Build asks the worker to run a user buildpack build for ba.
+func (w *workerClient) Build(ba buildArgs) error {
+	_, err := w.rpc.Call(rpc.MethodBuild, ba.toWorkerParams())
+	return err
+}
+
+// Cancel asks the worker to refuse any build that hasn't started yet. It
+// cannot interrupt a build already running - see workerServer.handleBuild.
+func (w *workerClient) Cancel() error {
+	_, err := w.rpc.Call(rpc.MethodCancel, nil)
+	return err
+}
+
+// Shutdown asks the worker to exit and waits for the subprocess.
+func (w *workerClient) Shutdown() error {
+	if _, err := w.rpc.Call(rpc.MethodShutdown, nil); err != nil {
+		return err
+	}
+	return w.proc.Wait()
+}
+
+func workerSocketPath() (string, error) {
+	dir, err := os.MkdirTemp("", "lifecycle-worker")
+	if err != nil {
+		return "", err
+	}
+	return dir + "/worker.sock", nil
+}
+
+// workerServer holds the state shared by the worker's rpc handlers: just
+// whether a Cancel has been requested. It's a struct (rather than closures
+// over a local variable, as runWorker used before) so handleBuild can be
+// called directly from a test without going through a real socket and
+// Serve loop.
+type workerServer struct {
+	cancelled cancelFlag
+}
+
+// handleCancel implements rpc.MethodCancel. Serve dispatches each request on
+// its own goroutine (see rpc.Serve), so this can run while handleBuild is
+// still in flight on another goroutine - but handleBuild only reads
+// cancelled once, before it does any work, so a Cancel that arrives after a
+// Build has already started has no effect until that Build returns on its
+// own. Interrupting a build already running would require plumbing a
+// cancellation context into builder.Build, which it does not currently
+// accept.
+func (ws *workerServer) handleCancel(params json.RawMessage, notify rpc.NotifyFunc) (interface{}, error) {
+	ws.cancelled.set()
+	return nil, nil
+}
+
+// handleBuild implements rpc.MethodBuild. See handleCancel's comment: the
+// cancelled check below only ever prevents a Build that hasn't started yet
+// from starting.
+func (ws *workerServer) handleBuild(params json.RawMessage, notify rpc.NotifyFunc) (interface{}, error) {
+	var p buildWorkerParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	ba := p.toBuildArgs()
+
+	if ws.cancelled.isSet() {
+		return nil, fmt.Errorf("build cancelled")
+	}
+
+	group, err := lifecycle.ReadGroup(ba.groupPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var plan lifecycle.BuildPlan
+	if _, err := toml.DecodeFile(ba.planPath, &plan); err != nil {
+		return nil, err
+	}
+
+	builder, err := ba.createBuilder(group, lifecycle.BuildpackGroup{}, plan,
+		notifyWriter{notify: notify}, notifyWriter{notify: notify, stderr: true})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ba.build(builder); err != nil {
+		return nil, err
+	}
+	return "ok", nil
+}
+
+// handleShutdown implements rpc.MethodShutdown.
+func (ws *workerServer) handleShutdown(params json.RawMessage, notify rpc.NotifyFunc) (interface{}, error) {
+	return "ok", nil
+}
+
+// runWorker is the entrypoint used when this binary is re-invoked as the
+// build worker (main() dispatches here when workerSocketFlag is set). It
+// dials back into the parent's listening socket, registers a workerServer's
+// handlers, and serves requests until told to shut down.
+func runWorker(sockPath string) error {
+	conn, err := net.Dial("unix", sockPath)
+	if err != nil {
+		return cmd.FailErr(err, "connect to worker socket")
+	}
+	defer conn.Close()
+
+	reg := rpc.NewRegistry()
+	ws := &workerServer{}
+	reg.Register(rpc.MethodCancel, ws.handleCancel)
+	reg.Register(rpc.MethodBuild, ws.handleBuild)
+	reg.Register(rpc.MethodShutdown, ws.handleShutdown)
+
+	return rpc.Serve(rpc.NewConn(conn), reg)
+}
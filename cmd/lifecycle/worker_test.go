@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestWorkerServerHandleBuild_RefusesAfterCancel exercises the real
+// workerServer.handleBuild registered as rpc.MethodBuild in runWorker, not a
+// stand-in: once handleCancel has run, a later handleBuild call refuses to
+// start rather than attempting to read ba.groupPath/ba.planPath (both empty
+// here, which would otherwise fail first and mask what's under test).
+func TestWorkerServerHandleBuild_RefusesAfterCancel(t *testing.T) {
+	ws := &workerServer{}
+
+	_, err := ws.handleCancel(nil, noopNotify)
+	require.NoError(t, err)
+
+	_, err = ws.handleBuild(json.RawMessage(`{}`), noopNotify)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "cancelled")
+}
+
+// TestWorkerServerHandleBuild_ProceedsWhenNotCancelled checks that with no
+// Cancel requested, handleBuild gets past its cancelled check and goes on to
+// do its work. handleBuild reads cancelled only once, before doing any work,
+// and builder.Build() accepts no cancellation channel to poll mid-build, so a
+// Cancel delivered after that check has no effect until the build finishes
+// on its own (see handleCancel's doc comment); this test does not exercise
+// that case.
+func TestWorkerServerHandleBuild_ProceedsWhenNotCancelled(t *testing.T) {
+	ws := &workerServer{}
+
+	// Bogus paths: handleBuild should fail trying to read ba.groupPath, not
+	// because of cancellation, showing the cancelled check passed.
+	params, err := json.Marshal(buildWorkerParams{GroupPath: "/does/not/exist", PlanPath: "/does/not/exist"})
+	require.NoError(t, err)
+
+	_, err = ws.handleBuild(params, noopNotify)
+	require.Error(t, err)
+	require.NotContains(t, err.Error(), "cancelled")
+}
+
+func noopNotify(method string, params interface{}) {}
@@ -0,0 +1,104 @@
+package rpc
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// Client issues JSON-RPC 2.0 calls over a Conn. A single background goroutine
+// reads the Conn and dispatches each Response to the Call that is waiting on
+// it, so multiple Calls (e.g. a MethodBuild in flight and a MethodCancel sent
+// to abort it) can safely run concurrently on the same Client. Any
+// Notification read in between is forwarded to OnNotify.
+type Client struct {
+	conn *Conn
+
+	mu      sync.Mutex
+	nextID  uint64
+	pending map[uint64]chan *Response
+	readErr error
+
+	// OnNotify, if set, is invoked for every Notification received.
+	OnNotify func(method string, params json.RawMessage)
+}
+
+// NewClient returns a Client that sends and receives over conn, and starts
+// the background goroutine that reads it.
+func NewClient(conn *Conn) *Client {
+	c := &Client{conn: conn, pending: map[uint64]chan *Response{}}
+	go c.readLoop()
+	return c
+}
+
+func (c *Client) readLoop() {
+	for {
+		msg, err := c.conn.Recv()
+		if err != nil {
+			c.closeWithError(err)
+			return
+		}
+
+		switch m := msg.(type) {
+		case *Notification:
+			if c.OnNotify != nil {
+				c.OnNotify(m.Method, m.Params)
+			}
+		case *Response:
+			c.mu.Lock()
+			ch, ok := c.pending[m.ID]
+			delete(c.pending, m.ID)
+			c.mu.Unlock()
+			if ok {
+				ch <- m
+			}
+		}
+	}
+}
+
+// closeWithError fails every Call still waiting on a Response, e.g. because
+// the underlying Conn was closed or errored.
+func (c *Client) closeWithError(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.readErr = err
+	for id, ch := range c.pending {
+		delete(c.pending, id)
+		ch <- &Response{Error: &Error{Message: err.Error()}}
+	}
+}
+
+// Call sends a request for method with params and blocks until the matching
+// Response arrives. It is safe to call Call concurrently from multiple
+// goroutines on the same Client.
+func (c *Client) Call(method string, params interface{}) (json.RawMessage, error) {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if c.readErr != nil {
+		err := c.readErr
+		c.mu.Unlock()
+		return nil, err
+	}
+	c.nextID++
+	id := c.nextID
+	ch := make(chan *Response, 1)
+	c.pending[id] = ch
+	c.mu.Unlock()
+
+	if err := c.conn.Send(&Request{JSONRPC: Version, ID: id, Method: method, Params: raw}); err != nil {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+		return nil, err
+	}
+
+	resp := <-ch
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+	return resp.Result, nil
+}
@@ -0,0 +1,68 @@
+package rpc
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Conn frames JSON-RPC 2.0 messages over an underlying stream as a sequence
+// of whitespace-separated JSON values, the same framing encoding/json uses
+// for streaming decode.
+//
+// Send is safe to call concurrently, since Serve dispatches each Request's
+// Handler on its own goroutine and those goroutines (plus any Notifications
+// they emit) all write to the same Conn.
+type Conn struct {
+	enc   *json.Encoder
+	dec   *json.Decoder
+	sendM sync.Mutex
+}
+
+// NewConn wraps rw (typically a Unix socket connection) in a Conn.
+func NewConn(rw io.ReadWriter) *Conn {
+	return &Conn{enc: json.NewEncoder(rw), dec: json.NewDecoder(rw)}
+}
+
+// Send writes v, which must be a *Request, *Response, or *Notification.
+func (c *Conn) Send(v interface{}) error {
+	c.sendM.Lock()
+	defer c.sendM.Unlock()
+	return c.enc.Encode(v)
+}
+
+// envelope is decoded first to distinguish a Request (has "id" and "method")
+// from a Response (has "id", no "method") from a Notification (has "method",
+// no "id").
+type envelope struct {
+	ID     *uint64 `json:"id"`
+	Method string  `json:"method"`
+}
+
+// Recv reads the next message off the wire and returns it as one of
+// *Request, *Response, or *Notification.
+func (c *Conn) Recv() (interface{}, error) {
+	var raw json.RawMessage
+	if err := c.dec.Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	var env envelope
+	if err := json.Unmarshal(raw, &env); err != nil {
+		return nil, errors.Wrap(err, "decode message envelope")
+	}
+
+	switch {
+	case env.ID != nil && env.Method != "":
+		req := &Request{}
+		return req, json.Unmarshal(raw, req)
+	case env.ID != nil:
+		resp := &Response{}
+		return resp, json.Unmarshal(raw, resp)
+	default:
+		note := &Notification{}
+		return note, json.Unmarshal(raw, note)
+	}
+}
@@ -0,0 +1,66 @@
+// Package rpc implements a minimal JSON-RPC 2.0 transport for driving a
+// persistent, unprivileged build worker over a Unix domain socket. It exists
+// so the lifecycle can replace a fork-exec-per-build subprocess with one
+// long-lived worker: requests carry the work to do, and notifications let the
+// worker stream buildpack stdout/stderr back to the caller while a request is
+// still in flight.
+package rpc
+
+import "encoding/json"
+
+// Version is the JSON-RPC protocol version this package implements.
+const Version = "2.0"
+
+// Request is a JSON-RPC 2.0 request object.
+type Request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      uint64          `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// Response is a JSON-RPC 2.0 response object. Exactly one of Result or Error
+// is set.
+type Response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      uint64          `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Notification is a one-way JSON-RPC 2.0 message with no ID. The worker uses
+// it to stream buildpack output to the caller in between a Request and its
+// Response.
+type Notification struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// Error is a JSON-RPC 2.0 error object.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Method names supported by the build worker.
+const (
+	MethodBuild      = "Build"
+	MethodStackBuild = "StackBuild"
+	MethodCancel     = "Cancel"
+	MethodShutdown   = "Shutdown"
+)
+
+// NotificationLog is the notification method used to stream a line of
+// buildpack stdout/stderr back to the caller.
+const NotificationLog = "log"
+
+// LogParams is the Params payload of a NotificationLog notification.
+type LogParams struct {
+	Text   string `json:"text"`
+	Stderr bool   `json:"stderr"`
+}
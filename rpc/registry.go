@@ -0,0 +1,32 @@
+package rpc
+
+import "encoding/json"
+
+// NotifyFunc streams a notification to the caller of the in-flight request.
+type NotifyFunc func(method string, params interface{})
+
+// Handler serves one JSON-RPC method call, emitting zero or more
+// notifications via notify before returning its result.
+type Handler func(params json.RawMessage, notify NotifyFunc) (interface{}, error)
+
+// Registry maps method names to the Handler that serves them.
+type Registry struct {
+	handlers map[string]Handler
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{handlers: map[string]Handler{}}
+}
+
+// Register installs h as the Handler for method, overwriting any existing
+// handler for that method.
+func (r *Registry) Register(method string, h Handler) {
+	r.handlers[method] = h
+}
+
+// Lookup returns the Handler registered for method, if any.
+func (r *Registry) Lookup(method string) (Handler, bool) {
+	h, ok := r.handlers[method]
+	return h, ok
+}
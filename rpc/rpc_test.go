@@ -0,0 +1,126 @@
+package rpc_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/buildpacks/lifecycle/rpc"
+)
+
+func TestClientServer(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+
+	reg := rpc.NewRegistry()
+	reg.Register("Echo", func(params json.RawMessage, notify rpc.NotifyFunc) (interface{}, error) {
+		notify("log", &rpc.LogParams{Text: "working\n"})
+
+		var in string
+		if err := json.Unmarshal(params, &in); err != nil {
+			return nil, err
+		}
+		return in, nil
+	})
+
+	go func() {
+		_ = rpc.Serve(rpc.NewConn(serverConn), reg)
+	}()
+
+	var notified []string
+	client := rpc.NewClient(rpc.NewConn(clientConn))
+	client.OnNotify = func(method string, params json.RawMessage) {
+		notified = append(notified, method)
+	}
+
+	result, err := client.Call("Echo", "hello")
+	require.NoError(t, err)
+
+	var out string
+	require.NoError(t, json.Unmarshal(result, &out))
+	require.Equal(t, "hello", out)
+	require.Equal(t, []string{"log"}, notified)
+}
+
+func TestClientCallUnknownMethod(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+
+	go func() {
+		_ = rpc.Serve(rpc.NewConn(serverConn), rpc.NewRegistry())
+	}()
+
+	client := rpc.NewClient(rpc.NewConn(clientConn))
+	_, err := client.Call("DoesNotExist", nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "method not found")
+}
+
+// TestServeDispatchesCancelConcurrently proves Serve dispatches MethodCancel
+// while MethodBuild's Handler is still blocked: if Serve read one Request to
+// completion before accepting the next, the Cancel call below would never
+// be seen until the 10s Build "finished" on its own, and this test would
+// time out waiting for buildErrCh.
+//
+// This only exercises the transport - the synthetic MethodBuild Handler here
+// polls a cancel channel while "running" so it can prove Serve delivers
+// Cancel promptly. It says nothing about whether the lifecycle's real
+// MethodBuild Handler reacts the same way mid-build; it doesn't, since
+// builder.Build() takes no cancellation channel to poll. See
+// workerServer.handleBuild in cmd/lifecycle, and its own test, for that.
+func TestServeDispatchesCancelConcurrently(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+
+	cancelCh := make(chan struct{})
+	var cancelOnce sync.Once
+
+	reg := rpc.NewRegistry()
+	reg.Register(rpc.MethodBuild, func(params json.RawMessage, notify rpc.NotifyFunc) (interface{}, error) {
+		select {
+		case <-cancelCh:
+			return nil, errors.New("build cancelled")
+		case <-time.After(10 * time.Second):
+			return "done", nil
+		}
+	})
+	reg.Register(rpc.MethodCancel, func(params json.RawMessage, notify rpc.NotifyFunc) (interface{}, error) {
+		cancelOnce.Do(func() { close(cancelCh) })
+		return "ok", nil
+	})
+
+	go func() {
+		_ = rpc.Serve(rpc.NewConn(serverConn), reg)
+	}()
+
+	client := rpc.NewClient(rpc.NewConn(clientConn))
+
+	start := time.Now()
+	buildErrCh := make(chan error, 1)
+	go func() {
+		_, err := client.Call(rpc.MethodBuild, nil)
+		buildErrCh <- err
+	}()
+
+	time.Sleep(50 * time.Millisecond) // give the Build call time to be dispatched
+
+	_, err := client.Call(rpc.MethodCancel, nil)
+	require.NoError(t, err)
+
+	select {
+	case buildErr := <-buildErrCh:
+		require.Error(t, buildErr)
+		require.Contains(t, buildErr.Error(), "cancelled")
+		require.Less(t, time.Since(start), 5*time.Second)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Cancel did not preempt the in-flight Build call")
+	}
+}
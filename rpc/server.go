@@ -0,0 +1,90 @@
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// Serve reads Requests off conn and dispatches each to the Handler
+// registered in reg on its own goroutine, writing back a Response and
+// relaying any notifications the Handler emits while it runs. Dispatching
+// concurrently (rather than handling one Request to completion before
+// reading the next) is what lets a MethodCancel request reach its Handler
+// while a MethodBuild Handler is still blocked running a build, instead of
+// queuing behind it. Whether that actually interrupts the in-flight work is
+// up to the MethodBuild Handler itself - Serve only guarantees Cancel is
+// delivered promptly, not that the running Handler acts on it mid-flight
+// (the lifecycle's own MethodBuild/MethodStackBuild handlers don't: see
+// workerServer.handleCancel in cmd/lifecycle).
+//
+// Serve returns when conn is closed, a request fails to decode, or a
+// MethodShutdown request is received; a MethodShutdown request first waits
+// for every Handler already dispatched to finish, so a shutdown is ordered
+// after in-flight work rather than racing it.
+func Serve(conn *Conn, reg *Registry) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		msg, err := conn.Recv()
+		if err != nil {
+			return err
+		}
+
+		req, ok := msg.(*Request)
+		if !ok {
+			// the worker only ever receives Requests on this connection;
+			// anything else is a protocol violation from a misbehaving peer
+			continue
+		}
+
+		if req.Method == MethodShutdown {
+			wg.Wait()
+			serveRequest(conn, reg, req)
+			return nil
+		}
+
+		wg.Add(1)
+		go func(req *Request) {
+			defer wg.Done()
+			serveRequest(conn, reg, req)
+		}(req)
+	}
+}
+
+func serveRequest(conn *Conn, reg *Registry, req *Request) {
+	handler, ok := reg.Lookup(req.Method)
+	if !ok {
+		_ = conn.Send(&Response{
+			JSONRPC: Version,
+			ID:      req.ID,
+			Error:   &Error{Code: -32601, Message: fmt.Sprintf("method not found: %s", req.Method)},
+		})
+		return
+	}
+
+	notify := func(method string, params interface{}) {
+		raw, err := json.Marshal(params)
+		if err != nil {
+			return
+		}
+		_ = conn.Send(&Notification{JSONRPC: Version, Method: method, Params: raw})
+	}
+
+	result, handlerErr := handler(req.Params, notify)
+
+	resp := &Response{JSONRPC: Version, ID: req.ID}
+	if handlerErr != nil {
+		resp.Error = &Error{Code: -32000, Message: handlerErr.Error()}
+	} else {
+		raw, err := json.Marshal(result)
+		if err != nil {
+			_ = conn.Send(&Response{JSONRPC: Version, ID: req.ID, Error: &Error{Code: -32000, Message: err.Error()}})
+			return
+		}
+		resp.Result = raw
+	}
+
+	_ = conn.Send(resp)
+}
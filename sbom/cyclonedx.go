@@ -0,0 +1,56 @@
+package sbom
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// cyclonedxDocument is a CycloneDX 1.4 BOM, populated with just the fields
+// the lifecycle needs to describe a stack buildpack's layer contents.
+type cyclonedxDocument struct {
+	BOMFormat   string               `json:"bomFormat"`
+	SpecVersion string               `json:"specVersion"`
+	Version     int                  `json:"version"`
+	Components  []cyclonedxComponent `json:"components"`
+}
+
+type cyclonedxComponent struct {
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	// PURL is the package URL, populated only for resolved OS packages.
+	PURL string `json:"purl,omitempty"`
+}
+
+func writeCycloneDX(path string, components []Component) error {
+	doc := cyclonedxDocument{
+		BOMFormat:   "CycloneDX",
+		SpecVersion: "1.4",
+		Version:     1,
+	}
+
+	for _, c := range components {
+		comp := cyclonedxComponent{Type: "file", Name: c.Name, Version: c.Version}
+		if c.OSPackage {
+			comp.Type = "library"
+			comp.PURL = "pkg:deb/" + c.Name + "@" + c.Version
+		}
+		doc.Components = append(doc.Components, comp)
+	}
+
+	return writeJSON(path, doc)
+}
+
+func writeJSON(path string, v interface{}) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,88 @@
+package sbom
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// osPackage is a single installed package resolved from the OS package
+// database.
+type osPackage struct {
+	Name    string
+	Version string
+}
+
+// osPackagesByPath indexes the packages installed under root by the absolute
+// path of every file each one owns, so a snapshot diff's files can be
+// attributed to the package that installed them. Only Debian's dpkg status
+// file is read for file ownership: a root with an RPM database (and no dpkg
+// status file), or with neither, returns an empty index, and every file in
+// the diff is then treated as loose.
+func osPackagesByPath(root string) (map[string]osPackage, error) {
+	if byPath, err := dpkgPackagesByPath(root); err == nil {
+		return byPath, nil
+	}
+	if byPath, err := rpmPackagesByPath(root); err == nil {
+		return byPath, nil
+	}
+	return map[string]osPackage{}, nil
+}
+
+// dpkgPackagesByPath parses /var/lib/dpkg/status and each package's
+// /var/lib/dpkg/info/<pkg>.list to map owned files to packages.
+func dpkgPackagesByPath(root string) (map[string]osPackage, error) {
+	statusPath := filepath.Join(root, "var/lib/dpkg/status")
+	f, err := os.Open(statusPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	byPath := map[string]osPackage{}
+	var current osPackage
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "Package: "):
+			current = osPackage{Name: strings.TrimPrefix(line, "Package: ")}
+		case strings.HasPrefix(line, "Version: "):
+			current.Version = strings.TrimPrefix(line, "Version: ")
+		case line == "" && current.Name != "":
+			addDpkgOwnedFiles(root, current, byPath)
+			current = osPackage{}
+		}
+	}
+	if current.Name != "" {
+		addDpkgOwnedFiles(root, current, byPath)
+	}
+
+	return byPath, scanner.Err()
+}
+
+func addDpkgOwnedFiles(root string, pkg osPackage, byPath map[string]osPackage) {
+	listPath := filepath.Join(root, "var/lib/dpkg/info", pkg.Name+".list")
+	f, err := os.Open(listPath)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		byPath[scanner.Text()] = pkg
+	}
+}
+
+// rpmPackagesByPath is a minimal placeholder for hosts using the RPM
+// package database: lifecycle images are overwhelmingly Debian-based today,
+// so a full rpm -qa / file-ownership query is left for when that changes.
+func rpmPackagesByPath(root string) (map[string]osPackage, error) {
+	if _, err := os.Stat(filepath.Join(root, "var/lib/rpm")); err != nil {
+		return nil, err
+	}
+	return map[string]osPackage{}, nil
+}
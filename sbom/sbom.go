@@ -0,0 +1,140 @@
+// Package sbom produces a bill of materials describing the files a
+// root-privileged stack buildpack added to or modified on the image's
+// rootfs - the phase most likely to install OS packages, and so the one most
+// in need of a supply-chain artifact.
+package sbom
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/buildpacks/lifecycle/snapshot"
+)
+
+// Format identifies an SBOM document format the Generator can emit, as
+// accepted (comma-separated) by the lifecycle's -sbom-formats flag.
+type Format string
+
+const (
+	FormatCycloneDX Format = "cyclonedx"
+	FormatSPDX      Format = "spdx"
+)
+
+// fileName is the name Generate writes each Format's document under, inside
+// layersDir/<bp-id>/.
+var fileName = map[Format]string{
+	FormatCycloneDX: "sbom.cdx.json",
+	FormatSPDX:      "sbom.spdx.json",
+}
+
+// Component is a single file or OS package captured from a stack buildpack's
+// snapshot diff.
+type Component struct {
+	// Name is the package name (for an OS package) or the file's absolute
+	// path on the rootfs, the same as Path (for a loose file).
+	Name string
+	// Version is the installed package version, empty for loose files.
+	Version string
+	// Path is the absolute path of the file on the rootfs.
+	Path string
+	// OSPackage is true if Name/Version were resolved from the OS package
+	// database rather than treated as a standalone file.
+	OSPackage bool
+}
+
+// Generator produces one or more SBOM documents describing layer's
+// contents, writing them under layersDir/<bpID>/.
+type Generator interface {
+	Generate(layer snapshot.Layer, layersDir, bpID string, formats []Format) error
+}
+
+// DefaultGenerator is the lifecycle's built-in Generator: it walks a
+// snapshot Layer's tar, classifies each entry as an OS package (by
+// consulting the dpkg database; files owned by RPM packages are not
+// attributed and count as loose files) or a loose file, and writes the
+// requested document formats.
+type DefaultGenerator struct {
+	// Root is the rootfs the OS package databases are read from, normally "/".
+	Root string
+}
+
+// Generate implements Generator.
+func (g DefaultGenerator) Generate(layer snapshot.Layer, layersDir, bpID string, formats []Format) error {
+	components, err := g.components(layer)
+	if err != nil {
+		return errors.Wrap(err, "classify snapshot layer")
+	}
+
+	outDir := filepath.Join(layersDir, bpID)
+	for _, format := range formats {
+		if err := writeDocument(format, outDir, components); err != nil {
+			return errors.Wrapf(err, "write %s sbom", format)
+		}
+	}
+	return nil
+}
+
+func (g DefaultGenerator) components(layer snapshot.Layer) ([]Component, error) {
+	paths, err := tarPaths(layer.TarPath)
+	if err != nil {
+		return nil, err
+	}
+
+	pkgsByPath, err := osPackagesByPath(g.root())
+	if err != nil {
+		return nil, err
+	}
+
+	components := make([]Component, 0, len(paths))
+	for _, path := range paths {
+		if pkg, ok := pkgsByPath[path]; ok {
+			components = append(components, Component{Name: pkg.Name, Version: pkg.Version, Path: path, OSPackage: true})
+			continue
+		}
+		components = append(components, Component{Name: path, Path: path})
+	}
+	return components, nil
+}
+
+func (g DefaultGenerator) root() string {
+	if g.Root == "" {
+		return "/"
+	}
+	return g.Root
+}
+
+func writeDocument(format Format, outDir string, components []Component) error {
+	name, ok := fileName[format]
+	if !ok {
+		return errors.Errorf("unknown sbom format '%s'", format)
+	}
+
+	switch format {
+	case FormatCycloneDX:
+		return writeCycloneDX(filepath.Join(outDir, name), components)
+	case FormatSPDX:
+		return writeSPDX(filepath.Join(outDir, name), components)
+	default:
+		return errors.Errorf("unknown sbom format '%s'", format)
+	}
+}
+
+// ParseFormats parses a comma-separated -sbom-formats flag value.
+func ParseFormats(commaSeparated string) ([]Format, error) {
+	var formats []Format
+	for _, f := range strings.Split(commaSeparated, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+
+		format := Format(f)
+		if _, ok := fileName[format]; !ok {
+			return nil, errors.Errorf("unknown sbom format '%s'", f)
+		}
+		formats = append(formats, format)
+	}
+	return formats, nil
+}
@@ -0,0 +1,130 @@
+package sbom_test
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/buildpacks/lifecycle/sbom"
+	"github.com/buildpacks/lifecycle/snapshot"
+)
+
+func TestParseFormats(t *testing.T) {
+	t.Run("parses a comma-separated list", func(t *testing.T) {
+		formats, err := sbom.ParseFormats("cyclonedx, spdx")
+		require.NoError(t, err)
+		require.Equal(t, []sbom.Format{sbom.FormatCycloneDX, sbom.FormatSPDX}, formats)
+	})
+
+	t.Run("rejects an unknown format", func(t *testing.T) {
+		_, err := sbom.ParseFormats("bogus")
+		require.Error(t, err)
+	})
+
+	t.Run("empty string yields no formats", func(t *testing.T) {
+		formats, err := sbom.ParseFormats("")
+		require.NoError(t, err)
+		require.Empty(t, formats)
+	})
+}
+
+func TestDefaultGenerator(t *testing.T) {
+	t.Run("writes a CycloneDX document describing the layer's files", func(t *testing.T) {
+		tmpDir := t.TempDir()
+
+		tarPath := filepath.Join(tmpDir, "layer.tar")
+		writeTestTar(t, tarPath, []string{"usr/local/bin/tool"})
+
+		layersDir := filepath.Join(tmpDir, "layers")
+		gen := sbom.DefaultGenerator{Root: tmpDir}
+
+		err := gen.Generate(snapshot.Layer{TarPath: tarPath}, layersDir, "some-stack-bp", []sbom.Format{sbom.FormatCycloneDX})
+		require.NoError(t, err)
+
+		data, err := os.ReadFile(filepath.Join(layersDir, "some-stack-bp", "sbom.cdx.json"))
+		require.NoError(t, err)
+
+		var doc map[string]interface{}
+		require.NoError(t, json.Unmarshal(data, &doc))
+		require.Equal(t, "CycloneDX", doc["bomFormat"])
+		require.Equal(t, "1.4", doc["specVersion"])
+
+		components, ok := doc["components"].([]interface{})
+		require.True(t, ok)
+		require.Len(t, components, 1)
+	})
+
+	t.Run("classifies a dpkg-owned file as an OS package", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		writeTestDpkgPackage(t, tmpDir, "curl", "7.81.0-1", []string{"/usr/bin/curl"})
+
+		tarPath := filepath.Join(tmpDir, "layer.tar")
+		writeTestTar(t, tarPath, []string{"usr/bin/curl"})
+
+		layersDir := filepath.Join(tmpDir, "layers")
+		gen := sbom.DefaultGenerator{Root: tmpDir}
+
+		err := gen.Generate(snapshot.Layer{TarPath: tarPath}, layersDir, "some-stack-bp", []sbom.Format{sbom.FormatCycloneDX})
+		require.NoError(t, err)
+
+		data, err := os.ReadFile(filepath.Join(layersDir, "some-stack-bp", "sbom.cdx.json"))
+		require.NoError(t, err)
+
+		var doc map[string]interface{}
+		require.NoError(t, json.Unmarshal(data, &doc))
+
+		components, ok := doc["components"].([]interface{})
+		require.True(t, ok)
+		require.Len(t, components, 1)
+
+		component, ok := components[0].(map[string]interface{})
+		require.True(t, ok)
+		require.Equal(t, "library", component["type"])
+		require.Equal(t, "curl", component["name"])
+		require.Equal(t, "7.81.0-1", component["version"])
+		require.Equal(t, "pkg:deb/curl@7.81.0-1", component["purl"])
+	})
+}
+
+// writeTestDpkgPackage seeds a minimal dpkg database under root describing a
+// single installed package that owns ownedFiles.
+func writeTestDpkgPackage(t *testing.T, root, name, version string, ownedFiles []string) {
+	t.Helper()
+
+	statusPath := filepath.Join(root, "var/lib/dpkg/status")
+	require.NoError(t, os.MkdirAll(filepath.Dir(statusPath), 0755))
+
+	status := "Package: " + name + "\nVersion: " + version + "\n\n"
+	require.NoError(t, os.WriteFile(statusPath, []byte(status), 0644))
+
+	listPath := filepath.Join(root, "var/lib/dpkg/info", name+".list")
+	require.NoError(t, os.MkdirAll(filepath.Dir(listPath), 0755))
+	require.NoError(t, os.WriteFile(listPath, []byte(strings.Join(ownedFiles, "\n")+"\n"), 0644))
+}
+
+func writeTestTar(t *testing.T, path string, files []string) {
+	t.Helper()
+
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	tw := tar.NewWriter(f)
+	defer tw.Close()
+
+	for _, name := range files {
+		content := []byte("test")
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Name: name,
+			Mode: 0644,
+			Size: int64(len(content)),
+		}))
+		_, err := tw.Write(content)
+		require.NoError(t, err)
+	}
+}
@@ -0,0 +1,46 @@
+package sbom
+
+import "strconv"
+
+// spdxDocument is an SPDX 2.3 document in its JSON form, populated with just
+// the fields the lifecycle needs to describe a stack buildpack's layer
+// contents.
+type spdxDocument struct {
+	SPDXVersion string        `json:"spdxVersion"`
+	DataLicense string        `json:"dataLicense"`
+	SPDXID      string        `json:"SPDXID"`
+	Name        string        `json:"name"`
+	Packages    []spdxPackage `json:"packages"`
+}
+
+type spdxPackage struct {
+	SPDXID           string `json:"SPDXID"`
+	Name             string `json:"name"`
+	VersionInfo      string `json:"versionInfo,omitempty"`
+	FilesAnalyzed    bool   `json:"filesAnalyzed"`
+	DownloadLocation string `json:"downloadLocation"`
+}
+
+func writeSPDX(path string, components []Component) error {
+	doc := spdxDocument{
+		SPDXVersion: "SPDX-2.3",
+		DataLicense: "CC0-1.0",
+		SPDXID:      "SPDXRef-DOCUMENT",
+		Name:        "stack-buildpack-layer",
+	}
+
+	for i, c := range components {
+		doc.Packages = append(doc.Packages, spdxPackage{
+			SPDXID:           spdxRefID(i),
+			Name:             c.Name,
+			VersionInfo:      c.Version,
+			DownloadLocation: "NOASSERTION",
+		})
+	}
+
+	return writeJSON(path, doc)
+}
+
+func spdxRefID(i int) string {
+	return "SPDXRef-Package-" + strconv.Itoa(i)
+}
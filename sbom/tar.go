@@ -0,0 +1,35 @@
+package sbom
+
+import (
+	"archive/tar"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// tarPaths returns the absolute rootfs path of every regular file entry in
+// the tar at tarPath.
+func tarPaths(tarPath string) ([]string, error) {
+	f, err := os.Open(tarPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var paths []string
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		paths = append(paths, filepath.Join("/", hdr.Name))
+	}
+	return paths, nil
+}
@@ -0,0 +1,30 @@
+package snapshot
+
+import "github.com/pkg/errors"
+
+// Kind identifies a Snapshotter implementation, as accepted by the
+// lifecycle's --snapshotter flag.
+type Kind string
+
+const (
+	KindKaniko  Kind = "kaniko"
+	KindOverlay Kind = "overlay"
+)
+
+// Factory constructs a Snapshotter for root. Platforms that need a custom
+// backend can set lifecycle.Builder.SnapshotterFactory to their own
+// implementation instead of using NewFactory's default dispatch.
+type Factory func(root string) (Snapshotter, error)
+
+// NewFactory returns the Factory for kind, the default dispatch used by
+// the lifecycle's --snapshotter flag.
+func NewFactory(kind Kind) (Factory, error) {
+	switch kind {
+	case KindKaniko, "":
+		return func(root string) (Snapshotter, error) { return NewKanikoSnapshotter(root) }, nil
+	case KindOverlay:
+		return func(root string) (Snapshotter, error) { return NewOverlaySnapshotter(root) }, nil
+	default:
+		return nil, errors.Errorf("unknown snapshotter '%s'", kind)
+	}
+}
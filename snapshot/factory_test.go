@@ -0,0 +1,29 @@
+package snapshot_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/buildpacks/lifecycle/snapshot"
+)
+
+func TestNewFactory(t *testing.T) {
+	t.Run("defaults to kaniko when kind is empty", func(t *testing.T) {
+		_, err := snapshot.NewFactory("")
+		require.NoError(t, err)
+	})
+
+	t.Run("accepts kaniko and overlay", func(t *testing.T) {
+		_, err := snapshot.NewFactory(snapshot.KindKaniko)
+		require.NoError(t, err)
+
+		_, err = snapshot.NewFactory(snapshot.KindOverlay)
+		require.NoError(t, err)
+	})
+
+	t.Run("rejects an unknown kind", func(t *testing.T) {
+		_, err := snapshot.NewFactory("bogus")
+		require.Error(t, err)
+	})
+}
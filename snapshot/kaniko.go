@@ -0,0 +1,65 @@
+package snapshot
+
+import (
+	"github.com/GoogleContainerTools/kaniko/pkg/snapshot"
+	"github.com/GoogleContainerTools/kaniko/pkg/util"
+)
+
+// KanikoSnapshotter captures filesystem changes by walking the entire
+// rootfs and comparing file metadata/content against the last snapshot, the
+// same approach Kaniko uses to build layers without a running Docker daemon.
+// It is correct for any filesystem but its cost scales with the size of
+// root, since every TakeSnapshot call re-walks it in full.
+type KanikoSnapshotter struct {
+	root string
+	snap *snapshot.Snapshotter
+}
+
+// NewKanikoSnapshotter returns a Snapshotter that diffs root by full
+// filesystem walk, in the style of Kaniko's layer builder.
+func NewKanikoSnapshotter(root string) (*KanikoSnapshotter, error) {
+	layeredMap := snapshot.NewLayeredMap(util.Hasher(), util.CacheHasher())
+	snap := snapshot.NewSnapshotter(layeredMap, root)
+
+	if err := snap.Init(); err != nil {
+		return nil, err
+	}
+
+	return &KanikoSnapshotter{root: root, snap: snap}, nil
+}
+
+// Root implements Snapshotter. Kaniko walks root in place, so stack
+// buildpacks need no redirection: Root is just the root it was constructed
+// with.
+func (k *KanikoSnapshotter) Root() string {
+	return k.root
+}
+
+// TakeSnapshot implements Snapshotter.
+func (k *KanikoSnapshotter) TakeSnapshot() (Layer, error) {
+	tarPath, err := k.snap.TakeSnapshotFS()
+	if err != nil {
+		return Layer{}, err
+	}
+
+	digest, err := fileDigest(tarPath)
+	if err != nil {
+		return Layer{}, err
+	}
+
+	return Layer{TarPath: tarPath, Digest: digest}, nil
+}
+
+// Reset implements Snapshotter by re-initializing the snapshotter's baseline
+// file map against the current state of root.
+func (k *KanikoSnapshotter) Reset() error {
+	return k.snap.Init()
+}
+
+// Close implements Snapshotter. Kaniko's snapshotter owns no resources
+// beyond in-memory state, so Close is a no-op.
+func (k *KanikoSnapshotter) Close() error {
+	return nil
+}
+
+var _ Snapshotter = (*KanikoSnapshotter)(nil)
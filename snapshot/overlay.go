@@ -0,0 +1,231 @@
+package snapshot
+
+import (
+	"archive/tar"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+)
+
+// whiteoutDevice is the (major, minor) device number overlayfs gives a
+// character-device whiteout entry in the upper dir: a char device with major
+// and minor both 0 marks a path as deleted relative to the lower dir.
+const whiteoutDevice = 0
+
+// whiteoutOpaqueXattr marks a directory in the upper dir as "opaque": none of
+// its contents in the lower dir should be visible, even though the directory
+// itself wasn't deleted and recreated.
+const whiteoutOpaqueXattr = "trusted.overlay.opaque"
+
+// OverlaySnapshotter captures filesystem changes by mounting root as the
+// lower dir of an overlayfs and tarring up whatever stack buildpacks write
+// into the upper dir. Unlike KanikoSnapshotter it never walks root itself,
+// so its cost scales with the size of the *changes*, not the size of root.
+// Overlayfs and the OCI image spec represent a deleted path differently -
+// overlayfs as a char device with a 0:0 device number, OCI as a zero-length
+// ".wh.<name>" marker file - so TakeSnapshot converts the former into the
+// latter as it tars the upper dir, giving the resulting layer the same
+// whiteout semantics tools built against OCI layers (including Kaniko's
+// output) expect. This does not make the two snapshotters' digests
+// byte-identical - tar entry order and per-file metadata still differ - only
+// semantically equivalent for the same set of changes.
+type OverlaySnapshotter struct {
+	root      string
+	workDir   string
+	upperDir  string
+	mergedDir string
+	mounted   bool
+}
+
+// NewOverlaySnapshotter mounts root as the lower dir of a fresh overlayfs and
+// returns a Snapshotter whose TakeSnapshot calls tar the overlay's upper dir.
+func NewOverlaySnapshotter(root string) (*OverlaySnapshotter, error) {
+	base, err := os.MkdirTemp("", "lifecycle-overlay")
+	if err != nil {
+		return nil, err
+	}
+
+	o := &OverlaySnapshotter{
+		root:      root,
+		workDir:   filepath.Join(base, "work"),
+		upperDir:  filepath.Join(base, "upper"),
+		mergedDir: filepath.Join(base, "merged"),
+	}
+
+	for _, dir := range []string{o.workDir, o.upperDir, o.mergedDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, errors.Wrapf(err, "create overlay dir '%s'", dir)
+		}
+	}
+
+	if err := o.mount(); err != nil {
+		return nil, err
+	}
+
+	return o, nil
+}
+
+// Root implements Snapshotter. It is the overlay's merged view (lower dir
+// plus upper dir), not o.root: a stack buildpack must write under this path,
+// not the real root, for TakeSnapshot to see the change in the upper dir it
+// tars. The caller (see mountSnapshotRoot in cmd/lifecycle) is responsible
+// for making that true, e.g. by bind-mounting Root over the real root before
+// running stack buildpacks.
+func (o *OverlaySnapshotter) Root() string {
+	return o.mergedDir
+}
+
+func (o *OverlaySnapshotter) mount() error {
+	opts := "lowerdir=" + o.root + ",upperdir=" + o.upperDir + ",workdir=" + o.workDir
+	if err := unix.Mount("overlay", o.mergedDir, "overlay", 0, opts); err != nil {
+		return errors.Wrap(err, "mount overlay")
+	}
+	o.mounted = true
+	return nil
+}
+
+// TakeSnapshot implements Snapshotter by tarring the overlay's upper dir,
+// which contains exactly the files a stack buildpack created, modified, or
+// whited-out relative to root.
+func (o *OverlaySnapshotter) TakeSnapshot() (Layer, error) {
+	f, err := os.CreateTemp("", "lifecycle-overlay-layer-*.tar")
+	if err != nil {
+		return Layer{}, err
+	}
+	defer f.Close()
+
+	tw := tar.NewWriter(f)
+	if err := filepath.Walk(o.upperDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == o.upperDir {
+			return nil
+		}
+
+		rel, err := filepath.Rel(o.upperDir, path)
+		if err != nil {
+			return err
+		}
+
+		if isWhiteoutDevice(info) {
+			return tw.WriteHeader(&tar.Header{
+				Name:     filepath.Join(filepath.Dir(rel), ".wh."+filepath.Base(rel)),
+				Typeflag: tar.TypeReg,
+				Mode:     int64(info.Mode().Perm()),
+			})
+		}
+
+		linkname := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			linkname, err = os.Readlink(path)
+			if err != nil {
+				return errors.Wrapf(err, "readlink '%s'", path)
+			}
+		}
+
+		hdr, err := tar.FileInfoHeader(info, linkname)
+		if err != nil {
+			return err
+		}
+		hdr.Name = rel
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			file, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			if _, err := io.Copy(tw, file); err != nil {
+				return err
+			}
+		}
+
+		if info.IsDir() && isOpaqueDir(path) {
+			if err := tw.WriteHeader(&tar.Header{Name: filepath.Join(rel, ".wh..wh..opq"), Typeflag: tar.TypeReg}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return Layer{}, errors.Wrap(err, "tar overlay upper dir")
+	}
+	if err := tw.Close(); err != nil {
+		return Layer{}, err
+	}
+
+	digest, err := fileDigest(f.Name())
+	if err != nil {
+		return Layer{}, err
+	}
+
+	return Layer{TarPath: f.Name(), Digest: digest}, nil
+}
+
+// Reset implements Snapshotter by unmounting and re-mounting the overlay
+// with a fresh, empty upper dir, so the next TakeSnapshot only captures
+// changes made since Reset.
+func (o *OverlaySnapshotter) Reset() error {
+	if err := o.unmount(); err != nil {
+		return err
+	}
+
+	if err := os.RemoveAll(o.upperDir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(o.upperDir, 0755); err != nil {
+		return err
+	}
+
+	return o.mount()
+}
+
+// Close implements Snapshotter by unmounting the overlay and removing its
+// scratch directories.
+func (o *OverlaySnapshotter) Close() error {
+	if err := o.unmount(); err != nil {
+		return err
+	}
+	return os.RemoveAll(filepath.Dir(o.workDir))
+}
+
+func (o *OverlaySnapshotter) unmount() error {
+	if !o.mounted {
+		return nil
+	}
+	if err := unix.Unmount(o.mergedDir, 0); err != nil {
+		return errors.Wrap(err, "unmount overlay")
+	}
+	o.mounted = false
+	return nil
+}
+
+// isWhiteoutDevice reports whether info is an overlayfs whiteout entry: a
+// character device with major and minor numbers both whiteoutDevice.
+func isWhiteoutDevice(info os.FileInfo) bool {
+	if info.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return false
+	}
+	return unix.Major(uint64(stat.Rdev)) == whiteoutDevice && unix.Minor(uint64(stat.Rdev)) == whiteoutDevice
+}
+
+// isOpaqueDir reports whether the directory at path is marked opaque via
+// overlayfs's trusted.overlay.opaque xattr.
+func isOpaqueDir(path string) bool {
+	buf := make([]byte, 1)
+	n, err := unix.Getxattr(path, whiteoutOpaqueXattr, buf)
+	return err == nil && n == 1 && buf[0] == 'y'
+}
+
+var _ Snapshotter = (*OverlaySnapshotter)(nil)
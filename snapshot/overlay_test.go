@@ -0,0 +1,59 @@
+package snapshot_test
+
+import (
+	"archive/tar"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/buildpacks/lifecycle/snapshot"
+)
+
+// TestOverlaySnapshotter exercises a real mount/write/snapshot cycle: it
+// requires CAP_SYS_ADMIN to mount overlayfs, same as NewOverlaySnapshotter
+// itself, so it's skipped when not running as root.
+func TestOverlaySnapshotter(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("overlayfs mounts require root")
+	}
+
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "existing.txt"), []byte("lower"), 0644))
+
+	o, err := snapshot.NewOverlaySnapshotter(root)
+	require.NoError(t, err)
+	defer o.Close()
+
+	// A stack buildpack writes under o.Root(), the overlay's merged view, not
+	// root itself - that's the path cmd/lifecycle bind-mounts over the real
+	// root before running stack buildpacks (see mountSnapshotRoot).
+	require.NoError(t, os.WriteFile(filepath.Join(o.Root(), "new-file.txt"), []byte("upper"), 0644))
+
+	layer, err := o.TakeSnapshot()
+	require.NoError(t, err)
+
+	require.Contains(t, tarEntryNames(t, layer.TarPath), "new-file.txt")
+}
+
+func tarEntryNames(t *testing.T, tarPath string) []string {
+	t.Helper()
+
+	f, err := os.Open(tarPath)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var names []string
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		names = append(names, hdr.Name)
+	}
+	return names
+}
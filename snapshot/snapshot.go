@@ -0,0 +1,37 @@
+// Package snapshot captures the filesystem mutations a root-privileged stack
+// buildpack makes to the image's rootfs, turning them into a single
+// reproducible layer.
+package snapshot
+
+// Layer is a single filesystem layer produced by a Snapshotter, as a tar
+// stream plus its content digest.
+type Layer struct {
+	// TarPath is the path to the layer's contents, written as an uncompressed tar.
+	TarPath string
+	// Digest is the sha256 digest of the layer's tar contents.
+	Digest string
+}
+
+// Snapshotter captures the filesystem changes made since the lifecycle
+// started (or since the last Reset) as a single Layer. Implementations are
+// not expected to be safe for concurrent use.
+type Snapshotter interface {
+	// Root returns the path a stack buildpack must write under in order for
+	// those writes to show up in TakeSnapshot's result. For a Snapshotter
+	// that diffs the real root in place (e.g. KanikoSnapshotter) this is the
+	// root it was constructed with; for one that diffs a side directory (e.g.
+	// OverlaySnapshotter's merged view) it is not, and the caller is
+	// responsible for making sure stack buildpacks actually execute against
+	// Root rather than the real root.
+	Root() string
+	// TakeSnapshot diffs the current filesystem state against the snapshot's
+	// baseline and returns the result as a Layer.
+	TakeSnapshot() (Layer, error)
+	// Reset re-establishes the baseline a later TakeSnapshot call diffs
+	// against, so a single Snapshotter can be reused across multiple stack
+	// buildpacks in a group.
+	Reset() error
+	// Close releases any resources (mounts, open file descriptors) held by
+	// the Snapshotter.
+	Close() error
+}